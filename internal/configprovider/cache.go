@@ -0,0 +1,99 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.uber.org/zap"
+)
+
+// StorageClient is the persistence API config source caching relies on from a storage
+// extension - the same storage.Client interface the exporter persistent queue consumes
+// from its own storage extension.
+type StorageClient = storage.Client
+
+// StorageExtension is implemented by an extension that can hand out a StorageClient
+// scoped to a caller-chosen component kind, ID and storage name, so unrelated
+// components sharing one storage extension don't collide on keys. This is the same
+// storage.Extension interface real storage extensions (file_storage, etc.) implement.
+type StorageExtension = storage.Extension
+
+// cacheConfig is the resolved, per-source-type caching configuration derived from
+// Settings.StorageID et al. when the manager was built.
+type cacheConfig struct {
+	ttl          time.Duration
+	staleIfError bool
+}
+
+// cacheEntry is what gets persisted in the storage extension for a single cached
+// retrieval.
+type cacheEntry struct {
+	Value       any       `json:"value"`
+	RetrievedAt time.Time `json:"retrieved_at"`
+}
+
+// cacheKey derives a storage key from a retrieval's identity. Two calls for the same
+// config source type, selector and params always land on the same key, so a later
+// Retrieve can find what an earlier one persisted.
+func cacheKey(sourceType, selector string, params any) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00", sourceType, selector)
+	if params != nil {
+		if b, err := json.Marshal(params); err == nil {
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readCache returns the cached entry for key, or an error if there is none or it
+// can't be decoded.
+func readCache(ctx context.Context, client StorageClient, key string) (cacheEntry, error) {
+	b, err := client.Get(ctx, key)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	if b == nil {
+		return cacheEntry{}, fmt.Errorf("no cached value for key %q", key)
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return cacheEntry{}, err
+	}
+	return entry, nil
+}
+
+// writeCache persists value under key, logging (rather than failing the retrieval)
+// if the storage extension can't accept the write - a stale cache is recoverable,
+// a failed config resolve is not.
+func writeCache(ctx context.Context, client StorageClient, key string, value any, logger *zap.Logger) {
+	entry := cacheEntry{Value: value, RetrievedAt: time.Now()}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		logger.Warn("failed to marshal config source value for caching", zap.Error(err))
+		return
+	}
+	if err := client.Set(ctx, key, b); err != nil {
+		logger.Warn("failed to persist config source value to storage extension", zap.Error(err))
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.uber.org/zap"
+)
+
+// fakeStorageClient is an in-memory StorageClient used to exercise the cache without
+// a real storage extension.
+type fakeStorageClient struct {
+	mu     sync.Mutex
+	data   map[string][]byte
+	closed bool
+
+	// setCh, when non-nil, receives a value after every Set call so a test can block
+	// until a background refreshCache goroutine has persisted its result.
+	setCh chan struct{}
+}
+
+var _ StorageClient = (*fakeStorageClient)(nil)
+
+func newFakeStorageClient() *fakeStorageClient {
+	return &fakeStorageClient{data: map[string][]byte{}}
+}
+
+func (c *fakeStorageClient) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *fakeStorageClient) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	c.data[key] = value
+	c.mu.Unlock()
+	if c.setCh != nil {
+		c.setCh <- struct{}{}
+	}
+	return nil
+}
+
+func (c *fakeStorageClient) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *fakeStorageClient) Batch(ctx context.Context, ops ...storage.Operation) error {
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			v, err := c.Get(ctx, op.Key)
+			if err != nil {
+				return err
+			}
+			op.Value = v
+		case storage.Set:
+			if err := c.Set(ctx, op.Key, op.Value); err != nil {
+				return err
+			}
+		case storage.Delete:
+			if err := c.Delete(ctx, op.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *fakeStorageClient) Close(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+// fakeStorageExtension hands out a single fakeStorageClient, mimicking an in-memory
+// storage extension that survives for the lifetime of the test.
+type fakeStorageExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	client *fakeStorageClient
+}
+
+var _ StorageExtension = (*fakeStorageExtension)(nil)
+
+func (f *fakeStorageExtension) GetClient(context.Context, component.Kind, component.ID, string) (StorageClient, error) {
+	return f.client, nil
+}
+
+// fakeHost exposes a fixed extension map, the only part of component.Host the
+// manager's SetHost needs.
+type fakeHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *fakeHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+func TestManagerCache_HitWithinTTLServesCachedValue(t *testing.T) {
+	storageID := component.NewID(component.MustNewType("fakestorage"))
+	client := newFakeStorageClient()
+	host := &fakeHost{extensions: map[component.ID]component.Component{
+		storageID: &fakeStorageExtension{client: client},
+	}}
+
+	source := newMockConfigSource(map[string]any{"db_password": "first-value"})
+	factory := &mockCfgSrcFactory{Source: source, storageID: &storageID, cacheTTL: time.Minute}
+
+	m, err := NewManager([]Factory{factory}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	require.NoError(t, m.SetHost(context.Background(), host))
+
+	v1, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first-value", v1)
+
+	// Rotate the underlying value without touching the cache - a second retrieval
+	// within the TTL window must still see the originally cached value.
+	source.Values["db_password"] = "second-value"
+	v2, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first-value", v2)
+}
+
+func TestManagerCache_StaleIfErrorFallsBackToLastGoodValue(t *testing.T) {
+	storageID := component.NewID(component.MustNewType("fakestorage"))
+	client := newFakeStorageClient()
+	host := &fakeHost{extensions: map[component.ID]component.Component{
+		storageID: &fakeStorageExtension{client: client},
+	}}
+
+	source := newMockConfigSource(map[string]any{"db_password": "good-value"})
+	factory := &mockCfgSrcFactory{Source: source, storageID: &storageID, staleIfError: true}
+
+	m, err := NewManager([]Factory{factory}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	require.NoError(t, m.SetHost(context.Background(), host))
+
+	v1, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "good-value", v1)
+
+	// CacheTTL is zero, so the second call revalidates against the source; simulate
+	// the secrets backend going down and expect the last persisted value instead of
+	// a hard failure.
+	source.errOnRetrieve = errors.New("backend unreachable")
+	v2, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "good-value", v2)
+}
+
+func TestManagerCache_HitWithinTTLRotatesCacheInBackground(t *testing.T) {
+	storageID := component.NewID(component.MustNewType("fakestorage"))
+	client := newFakeStorageClient()
+	client.setCh = make(chan struct{}, 2)
+	host := &fakeHost{extensions: map[component.ID]component.Component{
+		storageID: &fakeStorageExtension{client: client},
+	}}
+
+	source := newMockConfigSource(map[string]any{"db_password": "first-value"})
+	factory := &mockCfgSrcFactory{Source: source, storageID: &storageID, cacheTTL: time.Minute}
+
+	m, err := NewManager([]Factory{factory}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	require.NoError(t, m.SetHost(context.Background(), host))
+
+	_, err = m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	<-client.setCh // the retrieval above persists the initial value
+
+	// Rotate the underlying value; the next Retrieve is still within the TTL window,
+	// so it serves the cached value but kicks off an async refresh behind it.
+	source.Values["db_password"] = "second-value"
+	v, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first-value", v)
+
+	select {
+	case <-client.setCh:
+	case <-time.After(time.Second):
+		t.Fatal("background refreshCache never persisted a refreshed value")
+	}
+
+	entry, err := readCache(context.Background(), client, cacheKey("tstcfgsrc", "db_password", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "second-value", entry.Value)
+
+	require.NoError(t, m.Shutdown(context.Background()))
+}
+
+func TestManagerCache_ExpiredTTLRevalidatesAndOverwritesCache(t *testing.T) {
+	storageID := component.NewID(component.MustNewType("fakestorage"))
+	client := newFakeStorageClient()
+	host := &fakeHost{extensions: map[component.ID]component.Component{
+		storageID: &fakeStorageExtension{client: client},
+	}}
+
+	source := newMockConfigSource(map[string]any{"db_password": "first-value"})
+	factory := &mockCfgSrcFactory{Source: source, storageID: &storageID, cacheTTL: time.Millisecond}
+
+	m, err := NewManager([]Factory{factory}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	require.NoError(t, m.SetHost(context.Background(), host))
+
+	v1, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first-value", v1)
+
+	time.Sleep(10 * time.Millisecond) // let the TTL elapse
+	source.Values["db_password"] = "second-value"
+
+	v2, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second-value", v2)
+
+	entry, err := readCache(context.Background(), client, cacheKey("tstcfgsrc", "db_password", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "second-value", entry.Value)
+}
+
+// TestManagerCache_SettingsFromRawConfigSources verifies that the storage extension
+// ID, TTL and stale-if-error flag can actually come from the operator's own
+// "config_sources" section - the factory here leaves all three at their zero value,
+// so caching only turns on at all because rawConfigSources overrides it.
+func TestManagerCache_SettingsFromRawConfigSources(t *testing.T) {
+	storageID := component.NewID(component.MustNewType("fakestorage"))
+	client := newFakeStorageClient()
+	host := &fakeHost{extensions: map[component.ID]component.Component{
+		storageID: &fakeStorageExtension{client: client},
+	}}
+
+	source := newMockConfigSource(map[string]any{"db_password": "good-value"})
+	factory := &mockCfgSrcFactory{Source: source}
+
+	m, err := NewManager([]Factory{factory}, map[string]any{
+		"tstcfgsrc": map[string]any{
+			"storage":        storageID.String(),
+			"stale_if_error": true,
+		},
+	}, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	require.NoError(t, m.SetHost(context.Background(), host))
+
+	v1, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "good-value", v1)
+
+	source.errOnRetrieve = errors.New("backend unreachable")
+	v2, err := m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "good-value", v2, "stale_if_error from rawConfigSources should have served the cached value instead of propagating the error")
+}
+
+func TestManagerCache_NoStaleIfErrorPropagatesFailure(t *testing.T) {
+	storageID := component.NewID(component.MustNewType("fakestorage"))
+	client := newFakeStorageClient()
+	host := &fakeHost{extensions: map[component.ID]component.Component{
+		storageID: &fakeStorageExtension{client: client},
+	}}
+
+	source := newMockConfigSource(map[string]any{"db_password": "good-value"})
+	factory := &mockCfgSrcFactory{Source: source, storageID: &storageID}
+
+	m, err := NewManager([]Factory{factory}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	require.NoError(t, m.SetHost(context.Background(), host))
+
+	_, err = m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	require.NoError(t, err)
+
+	source.errOnRetrieve = errors.New("backend unreachable")
+	_, err = m.resolveString(context.Background(), "${tstcfgsrc:db_password}", nil)
+	assert.ErrorContains(t, err, "backend unreachable")
+}
@@ -0,0 +1,108 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// Settings is the base settings accepted by every config source. Factories embed it into
+// their own source-specific settings struct.
+type Settings struct {
+	// WatchEnabled controls whether the manager should attempt to fan-in update
+	// notifications from this config source. Sources that cannot watch for changes
+	// (or whose owner does not want them to) should leave this false: the manager
+	// treats it as an opt-in, never a requirement, so the collector keeps resolving
+	// normally when a source has no notion of "changed".
+	WatchEnabled bool `mapstructure:"watch_enabled"`
+
+	// StorageID, when set, names a storage extension the manager uses to persist this
+	// source's retrieved values across collector restarts - the same opt-in pattern
+	// the exporter persistent queue uses for its own storage extension. Leave nil to
+	// always talk to the source directly.
+	StorageID *component.ID `mapstructure:"storage"`
+
+	// CacheTTL bounds how long a persisted value is considered fresh enough to serve
+	// without re-contacting the source. A zero value means every Retrieve revalidates
+	// against the source, falling back to the cache only on failure and only when
+	// StaleIfError is set.
+	CacheTTL time.Duration `mapstructure:"cache_ttl"`
+
+	// StaleIfError, when true and StorageID is set, allows a persisted value to be
+	// served if the source can't be reached, instead of failing the retrieval. This is
+	// what lets the collector start up when a secrets backend is temporarily down.
+	StaleIfError bool `mapstructure:"stale_if_error"`
+}
+
+// decodeSettings unmarshals raw - the user-provided "config_sources.<type>" section of
+// the collector's configuration - over cfg, so any field the operator actually set
+// overrides the factory's default while everything else keeps it. This is what makes
+// watch_enabled, storage, cache_ttl and stale_if_error real, user-facing knobs instead
+// of only settable by a Factory author hardcoding them in CreateDefaultSettings.
+func decodeSettings(raw any, cfg *Settings) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.TextUnmarshallerHookFunc(),
+			stringToDurationHookFunc(),
+		),
+		Result: cfg,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(raw)
+}
+
+// CreateParams is passed to Factory.CreateConfigSource and carries everything a config
+// source implementation needs that isn't part of its own settings.
+type CreateParams struct {
+	TelemetrySettings component.TelemetrySettings
+
+	// BuildInfo identifies the collector build the config source is running in, e.g.
+	// so a source that opens an HTTP client (Vault, etcd) can set a meaningful
+	// User-Agent.
+	BuildInfo component.BuildInfo
+}
+
+// Retrieved holds the value produced by a ConfigSource.Retrieve call, mirroring the shape
+// of confmap.Retrieved so the manager can merge it back into the collector's configuration
+// without a second round of unmarshaling.
+type Retrieved struct {
+	Value any
+}
+
+// ConfigSource is the interface that selector-resolving implementations (Vault, etcd, a
+// Kubernetes secret watcher, etc.) must satisfy to be used in ${<type>:selector} expansions.
+type ConfigSource interface {
+	// Retrieve resolves selector (optionally narrowed by params) to a value.
+	//
+	// watcher, when non-nil, is the same confmap.WatcherFunc the collector's resolver
+	// handed to the wrapping confmap.Provider.Retrieve call. A ConfigSource that can
+	// detect that a previously returned value is stale (the secret rotated, the key
+	// disappeared, ...) may invoke it to request a re-resolve of the whole configuration.
+	// Implementations that have no such notion are free to ignore it entirely.
+	Retrieve(ctx context.Context, selector string, params any, watcher confmap.WatcherFunc) (*Retrieved, error)
+
+	// Shutdown signals that the configuration using this config source is being
+	// torn down and any resources held (connections, background watches, ...) should
+	// be released.
+	Shutdown(ctx context.Context) error
+}
@@ -0,0 +1,277 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configprovider implements a confmap.Provider that wraps one or more other
+// confmap.Provider instances (file, env, ...) and additionally expands config source
+// references (${<type>:selector}) found in the resolved configuration, using a set of
+// ConfigSource factories supplied by the embedder.
+package configprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// ProviderSettings configures a configSourceConfigMapProvider. It mirrors the shape of
+// confmap.ResolverSettings so embedders can register additional confmap.Provider and
+// confmap.Converter implementations directly, rather than having to wrap a pre-built
+// confmap.Provider before handing it to NewConfigSourceConfigMapProvider.
+type ProviderSettings struct {
+	// ProviderFactories builds the confmap.Provider instances used to fetch the raw
+	// configuration, keyed by the scheme each one reports via Provider.Scheme().
+	ProviderFactories []confmap.ProviderFactory
+
+	// ConverterFactories builds confmap.Converter instances that run, in order, over
+	// the raw configuration before config source references are expanded.
+	ConverterFactories []confmap.ConverterFactory
+
+	// ConfigSourceFactories builds the ConfigSource instances available for
+	// ${<type>:selector} expansion.
+	ConfigSourceFactories []Factory
+
+	// Hooks observe the provider's lifecycle; see the Hook type.
+	Hooks []Hook
+
+	// TelemetrySettings is forwarded to every ConfigSource this provider creates; each
+	// confmap.Provider it creates receives only its Logger, and confmap.Converter
+	// instances receive none of it.
+	TelemetrySettings component.TelemetrySettings
+
+	// BuildInfo is forwarded to every ConfigSource this provider creates, via
+	// CreateParams.BuildInfo.
+	BuildInfo component.BuildInfo
+}
+
+// configSourceConfigMapProvider is a confmap.Provider that delegates to a provider
+// selected from providerFactories (by URI scheme) to fetch the raw configuration, runs
+// it through converterFactories, and then resolves any config source references found
+// in it via manager.
+type configSourceConfigMapProvider struct {
+	providerFactories     []confmap.ProviderFactory
+	converterFactories    []confmap.ConverterFactory
+	configSourceFactories []Factory
+	buildInfo             component.BuildInfo
+
+	telemetry component.TelemetrySettings
+	hooks     []Hook
+
+	providersOnce sync.Once
+	providersMu   sync.Mutex
+	providers     map[string]confmap.Provider
+
+	// manager is built lazily, from the first Retrieve call, because that's the
+	// earliest point the "config_sources" section of the user's own configuration is
+	// available to decode each factory's Settings against - see ensureManager.
+	managerOnce sync.Once
+	manager     *Manager
+	buildErr    error
+}
+
+var _ confmap.Provider = (*configSourceConfigMapProvider)(nil)
+
+// HostAware is implemented by providers that need access to the collector's
+// extension graph once it is running, to resolve a storage extension for config
+// source caching (see Settings.StorageID). A confmap.Provider has no notion of a
+// component.Host on its own, so an embedder that knows it built its provider with
+// NewConfigSourceConfigMapProvider should type-assert to HostAware from whatever
+// lifecycle hook runs once extensions are started, and call SetHost exactly once.
+type HostAware interface {
+	SetHost(ctx context.Context, host component.Host) error
+}
+
+var _ HostAware = (*configSourceConfigMapProvider)(nil)
+
+// SetHost resolves the storage extensions backing any config source cache. Until it
+// is called, caching stays inactive and every Retrieve talks to config sources
+// directly, so calling it late is safe - it simply means earlier Retrieve calls
+// (most notably the very first one, before extensions exist) didn't benefit from a
+// persisted value. Called before the first Retrieve has built the manager, it does
+// nothing and returns nil, and caching stays inactive.
+func (cspp *configSourceConfigMapProvider) SetHost(ctx context.Context, host component.Host) error {
+	if cspp.manager == nil {
+		return cspp.buildErr
+	}
+	return cspp.manager.SetHost(ctx, host)
+}
+
+// NewConfigSourceConfigMapProvider creates a confmap.Provider that resolves config
+// source references in the configuration retrieved by set.ProviderFactories, using
+// set.ConfigSourceFactories to build the available config sources. The returned
+// provider is never nil, even if building the underlying manager fails: the error
+// surfaces from the first call to Retrieve instead, so callers can rely on a uniform
+// error path. set.ProviderFactories are not instantiated until the first Retrieve call,
+// but that first call builds every registered factory, not just the one matching the
+// URI's scheme - confmap.ProviderFactory has no way to report its Scheme() without
+// being built, so there's no way to tell which ones are needed ahead of time. An
+// embedder registering a provider with a side-effecting Create should expect to pay
+// that cost even for a scheme it never ends up using.
+func NewConfigSourceConfigMapProvider(set ProviderSettings) confmap.Provider {
+	cspp := &configSourceConfigMapProvider{
+		providerFactories:     set.ProviderFactories,
+		converterFactories:    set.ConverterFactories,
+		configSourceFactories: set.ConfigSourceFactories,
+		buildInfo:             set.BuildInfo,
+		telemetry:             set.TelemetrySettings,
+		hooks:                 set.Hooks,
+	}
+
+	for _, h := range set.Hooks {
+		h.OnNew()
+	}
+
+	return cspp
+}
+
+// configSourcesKey is the top-level key under which a collector configuration
+// declares per-config-source-type settings, e.g.:
+//
+//	config_sources:
+//	  vault:
+//	    watch_enabled: true
+//	    storage: file_storage
+//
+// It is popped out of the configuration before the rest is handed to the resolved
+// confmap.Conf, the same way the "extensions"/"receivers"/... sections are consumed by
+// the service graph rather than left for a downstream component to trip over.
+const configSourcesKey = "config_sources"
+
+// ensureManager builds the Manager the first time it's needed, decoding each
+// registered ConfigSourceFactory's Settings against rawConfigSources - the
+// "config_sources" section of whatever the first Retrieve call resolved. Later
+// Retrieve calls (e.g. a re-resolve after a watcher fires) reuse the same Manager and
+// its already-open config sources rather than rebuilding them from whatever
+// "config_sources" section happens to be in play at that moment.
+func (cspp *configSourceConfigMapProvider) ensureManager(rawConfigSources map[string]any) (*Manager, error) {
+	cspp.managerOnce.Do(func() {
+		cspp.manager, cspp.buildErr = NewManager(cspp.configSourceFactories, rawConfigSources, cspp.telemetry, cspp.buildInfo)
+	})
+	return cspp.manager, cspp.buildErr
+}
+
+// ensureProviders instantiates every registered confmap.ProviderFactory, the first time
+// any scheme is needed, and indexes the results by scheme. All of them are built, not
+// just the one providerFor ends up using, because a ProviderFactory only reveals its
+// Scheme() once it's been built.
+func (cspp *configSourceConfigMapProvider) ensureProviders() {
+	cspp.providersOnce.Do(func() {
+		cspp.providersMu.Lock()
+		defer cspp.providersMu.Unlock()
+
+		cspp.providers = make(map[string]confmap.Provider, len(cspp.providerFactories))
+		for _, f := range cspp.providerFactories {
+			p := f.Create(confmap.ProviderSettings{Logger: cspp.telemetry.Logger})
+			cspp.providers[p.Scheme()] = p
+		}
+	})
+}
+
+// providerFor returns the confmap.Provider registered for uri's scheme.
+func (cspp *configSourceConfigMapProvider) providerFor(uri string) (confmap.Provider, error) {
+	cspp.ensureProviders()
+
+	scheme := schemeOf(uri)
+	p, ok := cspp.providers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("no confmap.Provider registered for scheme %q", scheme)
+	}
+	return p, nil
+}
+
+// schemeOf returns the scheme portion of a confmap URI, i.e. everything before the
+// first ":".
+func schemeOf(uri string) string {
+	scheme, _, _ := strings.Cut(uri, ":")
+	return scheme
+}
+
+func (cspp *configSourceConfigMapProvider) Retrieve(ctx context.Context, uri string, watcher confmap.WatcherFunc) (*confmap.Retrieved, error) {
+	provider, err := cspp.providerFor(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	retrieved, err := provider.Retrieve(ctx, uri, watcher)
+	if err != nil {
+		return nil, err
+	}
+
+	conf, err := retrieved.AsConf()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cf := range cspp.converterFactories {
+		if err := cf.Create(confmap.ConverterSettings{}).Convert(ctx, conf); err != nil {
+			return nil, err
+		}
+	}
+
+	raw := conf.ToStringMap()
+	// The "config_sources" section configures the sources themselves (see
+	// ensureManager) - it isn't part of any component's configuration, so it's popped
+	// out here rather than left for a receiver/exporter/extension to trip over.
+	rawConfigSources, _ := raw[configSourcesKey].(map[string]any)
+	delete(raw, configSourcesKey)
+
+	manager, err := cspp.ensureManager(rawConfigSources)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := manager.Resolve(ctx, raw, watcher)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range cspp.hooks {
+		h.OnRetrieve(provider.Scheme(), resolved)
+	}
+
+	return confmap.NewRetrieved(resolved)
+}
+
+// Scheme implements confmap.Provider. A configSourceConfigMapProvider fronts every
+// scheme its provider factories support, so it has no single scheme of its own; it is
+// meant to be registered as the resolver's only provider, not composed under one.
+func (cspp *configSourceConfigMapProvider) Scheme() string {
+	return ""
+}
+
+func (cspp *configSourceConfigMapProvider) Shutdown(ctx context.Context) error {
+	defer func() {
+		for _, h := range cspp.hooks {
+			h.OnShutdown()
+		}
+	}()
+
+	cspp.providersMu.Lock()
+	providers := cspp.providers
+	cspp.providersMu.Unlock()
+
+	for _, p := range providers {
+		if err := p.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if cspp.manager != nil {
+		return cspp.manager.Shutdown(ctx)
+	}
+	return nil
+}
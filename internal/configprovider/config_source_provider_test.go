@@ -20,6 +20,7 @@ import (
 	"errors"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -30,6 +31,15 @@ import (
 	"go.uber.org/zap"
 )
 
+// newMockProviderFactory returns a confmap.ProviderFactory that always hands back p,
+// so tests can register a fixed provider without reaching into the provider's
+// internals after construction.
+func newMockProviderFactory(p confmap.Provider) confmap.ProviderFactory {
+	return confmap.NewProviderFactory(func(confmap.ProviderSettings) confmap.Provider {
+		return p
+	})
+}
+
 func TestConfigSourceConfigMapProvider(t *testing.T) {
 	tests := []struct {
 		parserProvider confmap.Provider
@@ -63,19 +73,19 @@ func TestConfigSourceConfigMapProvider(t *testing.T) {
 					ErrOnCreateConfigSource: errors.New("new_manager_builder_error forced error"),
 				},
 			},
-			parserProvider: fileprovider.New(),
+			parserProvider: fileprovider.NewFactory().Create(confmap.ProviderSettings{}),
 			configLocation: []string{"file:" + path.Join("testdata", "basic_config.yaml")},
 			wantErr:        "failed to create config source tstcfgsrc",
 		},
 		{
 			name:           "manager_resolve_error",
-			parserProvider: fileprovider.New(),
+			parserProvider: fileprovider.NewFactory().Create(confmap.ProviderSettings{}),
 			configLocation: []string{"file:" + path.Join("testdata", "manager_resolve_error.yaml")},
 			wantErr:        "config source \"tstcfgsrc\" failed to retrieve value: no value for selector \"selector\"",
 		},
 		{
 			name:           "multiple_config_success",
-			parserProvider: fileprovider.New(),
+			parserProvider: fileprovider.NewFactory().Create(confmap.ProviderSettings{}),
 			configLocation: []string{"file:" + path.Join("testdata", "arrays_and_maps_expected.yaml"),
 				"file:" + path.Join("testdata", "yaml_injection_expected.yaml")},
 		},
@@ -99,13 +109,18 @@ func TestConfigSourceConfigMapProvider(t *testing.T) {
 				h.On("OnShutdown")
 			}
 
-			pp := NewConfigSourceConfigMapProvider(
-				&mockParserProvider{},
-				zap.NewNop(),
-				component.NewDefaultBuildInfo(),
-				[]Hook{hookOne, hookTwo},
-				factories...,
-			)
+			parserProvider := tt.parserProvider
+			if parserProvider == nil {
+				parserProvider = &mockParserProvider{}
+			}
+			expectedScheme := parserProvider.Scheme()
+
+			pp := NewConfigSourceConfigMapProvider(ProviderSettings{
+				ProviderFactories:     []confmap.ProviderFactory{newMockProviderFactory(parserProvider)},
+				ConfigSourceFactories: factories,
+				Hooks:                 []Hook{hookOne, hookTwo},
+				TelemetrySettings:     component.TelemetrySettings{Logger: zap.NewNop()},
+			})
 			require.NotNil(t, pp)
 
 			for _, h := range hooks {
@@ -114,13 +129,7 @@ func TestConfigSourceConfigMapProvider(t *testing.T) {
 				h.AssertNotCalled(t, "OnShutdown")
 			}
 
-			var expectedScheme string
-			// Do not use the config.Default() to simplify the test setup.
 			cspp := pp.(*configSourceConfigMapProvider)
-			if tt.parserProvider != nil {
-				cspp.wrappedProvider = tt.parserProvider
-				expectedScheme = tt.parserProvider.Scheme()
-			}
 
 			// Need to run Retrieve method no matter what, so we can't just iterate passed in config locations
 			i := 0
@@ -167,6 +176,78 @@ func TestConfigSourceConfigMapProvider(t *testing.T) {
 	}
 }
 
+// TestConfigSourceConfigMapProvider_WatchForUpdate verifies that a config source
+// which emits an update after Retrieve has already returned still triggers the
+// confmap.WatcherFunc handed to Retrieve - this is what lets a Vault/etcd-backed
+// source signal a rotated secret without the collector polling it.
+func TestConfigSourceConfigMapProvider_WatchForUpdate(t *testing.T) {
+	source := newMockConfigSource(map[string]any{})
+	factory := &mockCfgSrcFactory{WatchEnabled: true, Source: source}
+
+	pp := NewConfigSourceConfigMapProvider(ProviderSettings{
+		ProviderFactories:     []confmap.ProviderFactory{newMockProviderFactory(fileprovider.NewFactory().Create(confmap.ProviderSettings{}))},
+		ConfigSourceFactories: []Factory{factory},
+		TelemetrySettings:     component.TelemetrySettings{Logger: zap.NewNop()},
+	})
+	defer func() { assert.NoError(t, pp.Shutdown(context.Background())) }()
+
+	events := make(chan *confmap.ChangeEvent, 1)
+	watcher := func(event *confmap.ChangeEvent) { events <- event }
+
+	r, err := pp.Retrieve(context.Background(), "file:"+path.Join("testdata", "basic_config.yaml"), watcher)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+
+	source.emitUpdate(errors.New("secret rotated"))
+
+	select {
+	case event := <-events:
+		assert.ErrorContains(t, event.Error, "secret rotated")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the watcher to be notified of the config source change")
+	}
+}
+
+// TestConfigSourceConfigMapProvider_SettingsFromConfigSourcesSection verifies that a
+// config source's Settings can actually be set by the operator, through the
+// "config_sources" section of their own configuration, rather than only by a Factory
+// author hardcoding them in CreateDefaultSettings. It also checks that the section
+// itself never reaches the resolved configuration - it configures the sources, it
+// isn't a component's own settings.
+func TestConfigSourceConfigMapProvider_SettingsFromConfigSourcesSection(t *testing.T) {
+	source := newMockConfigSource(map[string]any{})
+	// WatchEnabled defaults to false here; the YAML's "config_sources" section is
+	// what has to turn it on for the watcher below to ever fire.
+	factory := &mockCfgSrcFactory{Source: source}
+
+	pp := NewConfigSourceConfigMapProvider(ProviderSettings{
+		ProviderFactories:     []confmap.ProviderFactory{newMockProviderFactory(fileprovider.NewFactory().Create(confmap.ProviderSettings{}))},
+		ConfigSourceFactories: []Factory{factory},
+		TelemetrySettings:     component.TelemetrySettings{Logger: zap.NewNop()},
+	})
+	defer func() { assert.NoError(t, pp.Shutdown(context.Background())) }()
+
+	events := make(chan *confmap.ChangeEvent, 1)
+	watcher := func(event *confmap.ChangeEvent) { events <- event }
+
+	r, err := pp.Retrieve(context.Background(), "file:"+path.Join("testdata", "config_sources_section.yaml"), watcher)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+
+	resolved, err := r.AsConf()
+	require.NoError(t, err)
+	assert.NotContains(t, resolved.ToStringMap(), "config_sources")
+
+	source.emitUpdate(errors.New("secret rotated"))
+
+	select {
+	case event := <-events:
+		assert.ErrorContains(t, event.Error, "secret rotated")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the watcher to be notified of the config source change - watch_enabled from the config_sources section wasn't applied")
+	}
+}
+
 type mockParserProvider struct {
 	ErrOnGet bool
 }
@@ -0,0 +1,115 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+func TestDecodeSettings_OverridesOnlySpecifiedFields(t *testing.T) {
+	defaultStorageID := component.NewID(component.MustNewType("file_storage"))
+	cfg := Settings{StorageID: &defaultStorageID, CacheTTL: time.Minute}
+
+	err := decodeSettings(map[string]any{"watch_enabled": true}, &cfg)
+	require.NoError(t, err)
+
+	assert.True(t, cfg.WatchEnabled)
+	// Fields absent from raw keep whatever the factory defaulted them to.
+	assert.Equal(t, &defaultStorageID, cfg.StorageID)
+	assert.Equal(t, time.Minute, cfg.CacheTTL)
+}
+
+func TestDecodeSettings_DecodesDurationAndComponentID(t *testing.T) {
+	var cfg Settings
+
+	err := decodeSettings(map[string]any{
+		"storage":        "file_storage",
+		"cache_ttl":      "5m",
+		"stale_if_error": true,
+	}, &cfg)
+	require.NoError(t, err)
+
+	require.NotNil(t, cfg.StorageID)
+	assert.Equal(t, "file_storage", cfg.StorageID.String())
+	assert.Equal(t, 5*time.Minute, cfg.CacheTTL)
+	assert.True(t, cfg.StaleIfError)
+}
+
+func TestNewManager_SettingsComeFromRawConfigSources(t *testing.T) {
+	// WatchEnabled defaults to false on the factory; only rawConfigSources turns it on.
+	source := newMockConfigSource(map[string]any{})
+	factory := &mockCfgSrcFactory{Source: source}
+
+	m, err := NewManager([]Factory{factory}, map[string]any{
+		"tstcfgsrc": map[string]any{"watch_enabled": true},
+	}, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Shutdown(context.Background())) }()
+
+	events := make(chan *confmap.ChangeEvent, 1)
+	_, err = m.Resolve(context.Background(), map[string]any{}, func(event *confmap.ChangeEvent) { events <- event })
+	require.NoError(t, err)
+
+	source.emitUpdate(errors.New("secret rotated"))
+
+	select {
+	case event := <-events:
+		assert.ErrorContains(t, event.Error, "secret rotated")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for fan-in to notify the watcher - watch_enabled from rawConfigSources wasn't applied")
+	}
+}
+
+func TestNewManager_ShutsDownEarlierSourcesWhenALaterOneFailsToBuild(t *testing.T) {
+	earlier := newMockConfigSource(map[string]any{})
+	earlierFactory := &mockCfgSrcFactory{Source: earlier, WatchEnabled: true}
+	failingFactory := &mockFailingCfgSrcFactory{typ: "failing"}
+
+	_, err := NewManager([]Factory{earlierFactory, failingFactory}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.Error(t, err)
+
+	// earlier's Shutdown (and therefore its fan-in goroutine) must have been stopped,
+	// not leaked along with the Manager build NewManager just failed.
+	select {
+	case _, ok := <-earlier.updates:
+		assert.False(t, ok, "earlier config source's update channel should be closed by Shutdown")
+	case <-time.After(time.Second):
+		t.Fatal("earlier config source was never shut down after the later factory failed")
+	}
+}
+
+// mockFailingCfgSrcFactory always fails CreateConfigSource, simulating a factory later
+// in the list than one that already built (and started watching) successfully.
+type mockFailingCfgSrcFactory struct {
+	typ string
+}
+
+var _ Factory = (*mockFailingCfgSrcFactory)(nil)
+
+func (f *mockFailingCfgSrcFactory) Type() string                    { return f.typ }
+func (f *mockFailingCfgSrcFactory) CreateDefaultSettings() Settings { return Settings{} }
+func (f *mockFailingCfgSrcFactory) CreateConfigSource(CreateParams, Settings) (ConfigSource, error) {
+	return nil, errors.New("mockFailingCfgSrcFactory forced error")
+}
@@ -0,0 +1,168 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// builtinDecodeHookTarget pairs the built-in decode hooks with the type they coerce a
+// string into, seeding Manager.decodeHookTargets so a ${type:selector|name} pipe
+// reference knows what to decode into before any hook has been registered.
+var builtinDecodeHookTarget = map[string]reflect.Type{
+	"duration": reflect.TypeOf(time.Duration(0)),
+	"ip":       reflect.TypeOf(net.IP{}),
+	"url":      reflect.TypeOf(url.URL{}),
+}
+
+func stringToDurationHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(time.Duration(0)) {
+			return data, nil
+		}
+		return time.ParseDuration(data.(string))
+	}
+}
+
+func stringToIPHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(net.IP{}) {
+			return data, nil
+		}
+		ip := net.ParseIP(data.(string))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", data)
+		}
+		return ip, nil
+	}
+}
+
+func stringToURLHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(url.URL{}) {
+			return data, nil
+		}
+		u, err := url.Parse(data.(string))
+		if err != nil {
+			return nil, err
+		}
+		return *u, nil
+	}
+}
+
+// RegisterDecodeHook adds a named mapstructure.DecodeHookFunc that Retrieve[T] can
+// apply when coercing a config source's raw value into a typed result, and that the
+// ${type:selector|name} pipe syntax can apply too, decoding into targetType. This
+// mirrors how the built-ins in builtinDecodeHookTarget pair a hook with the type it
+// produces, so the pipe parser knows what to decode into before the caller has named
+// any destination type. Registering a name that already exists replaces it.
+func (m *Manager) RegisterDecodeHook(name string, targetType reflect.Type, hook mapstructure.DecodeHookFunc) {
+	m.decodeHooksMu.Lock()
+	defer m.decodeHooksMu.Unlock()
+	m.decodeHooks[name] = hook
+	m.decodeHookTargets[name] = targetType
+}
+
+func (m *Manager) composedDecodeHook() mapstructure.DecodeHookFunc {
+	m.decodeHooksMu.RLock()
+	defer m.decodeHooksMu.RUnlock()
+
+	hooks := make([]mapstructure.DecodeHookFunc, 0, len(m.decodeHooks))
+	for _, h := range m.decodeHooks {
+		hooks = append(hooks, h)
+	}
+	return mapstructure.ComposeDecodeHookFunc(hooks...)
+}
+
+// decodeValue decodes raw into a new value of targetType, running it through every
+// registered decode hook.
+func (m *Manager) decodeValue(raw any, targetType reflect.Type) (any, error) {
+	return m.decodeValueWithHook(raw, targetType, m.composedDecodeHook())
+}
+
+// decodeValueWithHook decodes raw into a new value of targetType using only hook,
+// rather than every registered decode hook.
+func (m *Manager) decodeValueWithHook(raw any, targetType reflect.Type, hook mapstructure.DecodeHookFunc) (any, error) {
+	out := reflect.New(targetType)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: hook,
+		Result:     out.Interface(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, err
+	}
+	return out.Elem().Interface(), nil
+}
+
+// applyDecodeHook coerces raw using the decode hook named hookName - built-in or
+// registered via RegisterDecodeHook - as referenced by a ${type:selector|hookName}
+// expansion. It invokes exactly that hook function, not every hook registered on m:
+// two hooks can target the same type (e.g. a custom hook alongside the built-in
+// "duration"), and naming one in the pipe must not leave the choice between them to
+// whichever happens to run first in the composed chain.
+func (m *Manager) applyDecodeHook(hookName string, raw any) (any, error) {
+	m.decodeHooksMu.RLock()
+	targetType, ok := m.decodeHookTargets[hookName]
+	hook, hookOk := m.decodeHooks[hookName]
+	m.decodeHooksMu.RUnlock()
+	if !ok || !hookOk {
+		return nil, fmt.Errorf("no decode hook registered for pipe %q", hookName)
+	}
+	return m.decodeValueWithHook(raw, targetType, hook)
+}
+
+// Retrieve resolves selector against the ConfigSource registered under sourceType and
+// decodes the result into T, running every decode hook registered on m via
+// RegisterDecodeHook. Use it directly (rather than a ${type:selector} string
+// expansion) when the destination is a struct a config source can't reasonably be
+// expected to know how to produce on its own, e.g.:
+//
+//	creds, err := configprovider.Retrieve[VaultCredentials](ctx, manager, "vault", "secret/db")
+func Retrieve[T any](ctx context.Context, m *Manager, sourceType, selector string) (T, error) {
+	var out T
+	// reflect.TypeOf(out) would return nil if T is an interface type and out is
+	// still its zero value; this form resolves T's static type regardless.
+	targetType := reflect.TypeOf((*T)(nil)).Elem()
+
+	cs, ok := m.sources[sourceType]
+	if !ok {
+		return out, fmt.Errorf("config source %q not found", sourceType)
+	}
+
+	// Go through retrieveRef, not cs.Retrieve directly, so a source configured with
+	// Settings.StorageID gets the same cache-backed, stale-if-error behavior here as
+	// it does for ${type:selector} string expansions.
+	value, err := m.retrieveRef(ctx, configSourceRef{sourceType: sourceType, selector: selector}, cs, nil)
+	if err != nil {
+		return out, err
+	}
+
+	decoded, err := m.decodeValue(value, targetType)
+	if err != nil {
+		return out, fmt.Errorf("config source %q: failed to decode value for selector %q: %w", sourceType, selector, err)
+	}
+	return decoded.(T), nil
+}
@@ -0,0 +1,141 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+func TestManagerResolveString_DecodeHookPipe(t *testing.T) {
+	source := newMockConfigSource(map[string]any{"timeout": "30s"})
+	m, err := NewManager([]Factory{&mockCfgSrcFactory{Source: source}}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+
+	v, err := m.resolveString(context.Background(), "${tstcfgsrc:timeout|duration}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, v)
+}
+
+func TestManagerResolveString_DecodeHookPipeUsesRegisteredHook(t *testing.T) {
+	source := newMockConfigSource(map[string]any{"created_at": "2026-01-01T00:00:00Z"})
+	m, err := NewManager([]Factory{&mockCfgSrcFactory{Source: source}}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+
+	m.RegisterDecodeHook("rfc3339_time", reflect.TypeOf(time.Time{}), stringToRFC3339HookFunc())
+
+	v, err := m.resolveString(context.Background(), "${tstcfgsrc:created_at|rfc3339_time}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2026, v.(time.Time).Year())
+}
+
+func TestManagerResolveString_DecodeHookPipeInvokesNamedHookOnly(t *testing.T) {
+	// "other_duration" targets time.Duration, the same type as the built-in
+	// "duration" hook, but always errors. Naming it in the pipe must deterministically
+	// run it - never silently fall through to "duration" just because both are in the
+	// composed chain.
+	source := newMockConfigSource(map[string]any{"timeout": "30s"})
+	m, err := NewManager([]Factory{&mockCfgSrcFactory{Source: source}}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+
+	erroringHook := func(from reflect.Type, to reflect.Type, _ any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(time.Duration(0)) {
+			return nil, nil
+		}
+		return nil, errors.New("other_duration forced error")
+	}
+	m.RegisterDecodeHook("other_duration", reflect.TypeOf(time.Duration(0)), erroringHook)
+
+	for i := 0; i < 30; i++ {
+		_, err := m.resolveString(context.Background(), "${tstcfgsrc:timeout|other_duration}", nil)
+		require.Error(t, err)
+	}
+}
+
+func TestManagerResolveString_LiteralPipeInSelectorIsNotTreatedAsHook(t *testing.T) {
+	// "admin" isn't a registered decode hook name, so the "|" here must stay part of
+	// the selector instead of being split off - otherwise any selector that happens
+	// to contain a literal pipe (e.g. an encoded credential) would silently change
+	// meaning once the decode-hook pipe syntax was introduced.
+	source := newMockConfigSource(map[string]any{"user|admin": "value"})
+	m, err := NewManager([]Factory{&mockCfgSrcFactory{Source: source}}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+
+	v, err := m.resolveString(context.Background(), "${tstcfgsrc:user|admin}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
+
+func TestManagerResolveString_UnrecognizedPipeSuffixKeptAsPartOfSelector(t *testing.T) {
+	// "not_a_hook" doesn't name a built-in decode hook, so it isn't split off - the
+	// whole string is looked up as the selector, same as before the pipe syntax
+	// existed.
+	source := newMockConfigSource(map[string]any{"timeout|not_a_hook": "30s"})
+	m, err := NewManager([]Factory{&mockCfgSrcFactory{Source: source}}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+
+	v, err := m.resolveString(context.Background(), "${tstcfgsrc:timeout|not_a_hook}", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "30s", v)
+}
+
+// vaultCredentials mimics a structured secret a real source (e.g. Vault) might
+// return as a map[string]any, which Retrieve[T] decodes for the caller.
+type vaultCredentials struct {
+	Username  string    `mapstructure:"username"`
+	Password  string    `mapstructure:"password"`
+	ExpiresAt time.Time `mapstructure:"expires_at"`
+}
+
+func TestRetrieve_DecodesStructuredValue(t *testing.T) {
+	source := newMockConfigSource(map[string]any{
+		"db": map[string]any{
+			"username":   "app",
+			"password":   "hunter2",
+			"expires_at": "2026-01-01T00:00:00Z",
+		},
+	})
+	m, err := NewManager([]Factory{&mockCfgSrcFactory{Source: source}}, nil, component.TelemetrySettings{Logger: zap.NewNop()}, component.BuildInfo{})
+	require.NoError(t, err)
+
+	m.RegisterDecodeHook("rfc3339_time", reflect.TypeOf(time.Time{}), stringToRFC3339HookFunc())
+
+	creds, err := Retrieve[vaultCredentials](context.Background(), m, "tstcfgsrc", "db")
+	require.NoError(t, err)
+	assert.Equal(t, "app", creds.Username)
+	assert.Equal(t, "hunter2", creds.Password)
+	assert.Equal(t, 2026, creds.ExpiresAt.Year())
+}
+
+// stringToRFC3339HookFunc is a user-supplied decode hook, exercising
+// Manager.RegisterDecodeHook with something other than a built-in.
+func stringToRFC3339HookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(time.Time{}) {
+			return data, nil
+		}
+		return time.Parse(time.RFC3339, data.(string))
+	}
+}
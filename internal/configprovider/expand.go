@@ -0,0 +1,74 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"regexp"
+	"strings"
+)
+
+// configSourceRefRegexp matches a whole-value reference to a config source, e.g.
+// "${tstcfgsrc:selector}", "${vault:secret/db#password}", or, with a trailing pipe
+// naming a decode hook - built-in or registered via RegisterDecodeHook -
+// "${vault:secret/db#password|duration}".
+// Partial-string interpolation (e.g. "prefix-${foo:bar}-suffix") is intentionally not
+// supported: a config source value can be of any type, so it can only be substituted
+// when it is the entire string.
+var configSourceRefRegexp = regexp.MustCompile(`^\$\{([^:}]+):([^}]*)\}$`)
+
+// configSourceRef is a parsed ${type:selector} or ${type:selector|hook} reference.
+type configSourceRef struct {
+	sourceType string
+	selector   string
+	decodeHook string
+}
+
+// parseConfigSourceRef returns the parsed reference and true if s is a whole-value
+// config source reference, or the zero value and false otherwise.
+func (m *Manager) parseConfigSourceRef(s string) (configSourceRef, bool) {
+	match := configSourceRefRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return configSourceRef{}, false
+	}
+	selector, hook := m.splitDecodeHookPipe(match[2])
+	return configSourceRef{sourceType: match[1], selector: selector, decodeHook: hook}, true
+}
+
+// splitDecodeHookPipe splits rest into a selector and a trailing "|<hook>" suffix,
+// but only when <hook> names a decode hook known to m - built-in or registered via
+// RegisterDecodeHook. This keeps the pipe syntax from hijacking selectors that happen
+// to contain a literal "|" (e.g. an encoded credential) for anything that isn't
+// actually a recognized hook name.
+func (m *Manager) splitDecodeHookPipe(rest string) (selector, hook string) {
+	idx := strings.LastIndex(rest, "|")
+	if idx < 0 {
+		return rest, ""
+	}
+	candidate := rest[idx+1:]
+	m.decodeHooksMu.RLock()
+	_, ok := m.decodeHookTargets[candidate]
+	m.decodeHooksMu.RUnlock()
+	if !ok {
+		return rest, ""
+	}
+	return rest[:idx], candidate
+}
+
+// isConfigSourceRef reports whether s looks like a ${type:selector} reference,
+// without fully validating it - used to short-circuit values that plainly aren't.
+func isConfigSourceRef(s string) bool {
+	return strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") && strings.Contains(s, ":")
+}
@@ -0,0 +1,33 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+// Factory is the interface that config source factories must implement so the manager
+// can build a ConfigSource for every type referenced by a configuration's
+// ${<type>:selector} expansions.
+type Factory interface {
+	// Type returns the scheme (e.g. "vault", "etcd2") that selects this factory
+	// when expanding ${<type>:selector} references.
+	Type() string
+
+	// CreateDefaultSettings returns the default Settings for this config source,
+	// to be unmarshaled against the user-provided configuration before
+	// CreateConfigSource is called.
+	CreateDefaultSettings() Settings
+
+	// CreateConfigSource creates a ConfigSource using the given settings.
+	CreateConfigSource(params CreateParams, cfg Settings) (ConfigSource, error)
+}
@@ -0,0 +1,111 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// mockCfgSrcFactory builds mockConfigSource instances under the "tstcfgsrc" scheme.
+type mockCfgSrcFactory struct {
+	ErrOnCreateConfigSource error
+	WatchEnabled            bool
+	Source                  *mockConfigSource
+
+	storageID    *component.ID
+	cacheTTL     time.Duration
+	staleIfError bool
+}
+
+var _ Factory = (*mockCfgSrcFactory)(nil)
+
+func (f *mockCfgSrcFactory) Type() string {
+	return "tstcfgsrc"
+}
+
+func (f *mockCfgSrcFactory) CreateDefaultSettings() Settings {
+	return Settings{
+		WatchEnabled: f.WatchEnabled,
+		StorageID:    f.storageID,
+		CacheTTL:     f.cacheTTL,
+		StaleIfError: f.staleIfError,
+	}
+}
+
+func (f *mockCfgSrcFactory) CreateConfigSource(CreateParams, Settings) (ConfigSource, error) {
+	if f.ErrOnCreateConfigSource != nil {
+		return nil, f.ErrOnCreateConfigSource
+	}
+	if f.Source != nil {
+		return f.Source, nil
+	}
+	return newMockConfigSource(map[string]any{}), nil
+}
+
+// mockConfigSource is a ConfigSource used by tests. Any selector named "selector"
+// mimics a lookup miss so tests can exercise the manager's error wrapping; any
+// selector present in Values resolves to that value verbatim (used to simulate a
+// source returning structured data, not just strings); anything else resolves to a
+// synthesized string so callers don't need to populate Values for the common case.
+type mockConfigSource struct {
+	Values        map[string]any
+	errOnRetrieve error
+	updates       chan error
+}
+
+var _ ConfigSource = (*mockConfigSource)(nil)
+
+// newMockConfigSource builds a mockConfigSource with its update channel already
+// allocated, so WatchForUpdate and emitUpdate never race over lazily creating it -
+// the manager's fan-in goroutine may call WatchForUpdate concurrently with a test
+// calling emitUpdate right after construction.
+func newMockConfigSource(values map[string]any) *mockConfigSource {
+	return &mockConfigSource{Values: values, updates: make(chan error, 1)}
+}
+
+func (m *mockConfigSource) Retrieve(_ context.Context, selector string, _ any, watcher confmap.WatcherFunc) (*Retrieved, error) {
+	if m.errOnRetrieve != nil {
+		return nil, m.errOnRetrieve
+	}
+	if selector == "selector" {
+		return nil, fmt.Errorf("no value for selector %q", selector)
+	}
+	if v, ok := m.Values[selector]; ok {
+		return &Retrieved{Value: v}, nil
+	}
+	return &Retrieved{Value: "retrieved_" + selector}, nil
+}
+
+func (m *mockConfigSource) Shutdown(context.Context) error {
+	close(m.updates)
+	return nil
+}
+
+// WatchForUpdate implements Watchable.
+func (m *mockConfigSource) WatchForUpdate() <-chan error {
+	return m.updates
+}
+
+// emitUpdate pushes a change notification, as a real source would after noticing a
+// rotated secret. Tests use it to simulate an update arriving after Retrieve returns.
+func (m *mockConfigSource) emitUpdate(err error) {
+	m.updates <- err
+}
@@ -0,0 +1,33 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+// Hook allows embedders to observe the lifecycle of a configSourceConfigMapProvider
+// without needing to reimplement it. Hooks are invoked synchronously, in the order
+// they were supplied to NewConfigSourceConfigMapProvider.
+type Hook interface {
+	// OnNew is called once the provider has been built. The underlying manager does
+	// not exist yet at that point: it is built by the first Retrieve call.
+	OnNew()
+
+	// OnRetrieve is called after a successful Retrieve, once the returned
+	// configuration has been fully resolved. scheme is the scheme of the wrapped
+	// confmap.Provider that supplied the raw configuration (e.g. "file", "env").
+	OnRetrieve(scheme string, conf map[string]any)
+
+	// OnShutdown is called once, when the provider is shut down.
+	OnShutdown()
+}
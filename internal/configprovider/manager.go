@@ -0,0 +1,377 @@
+// Copyright Splunk, Inc.
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configprovider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+// configSourceOwnerID names this package as the owner of any storage client it opens,
+// the same way exporterhelper tags its persistent-queue storage clients.
+var configSourceOwnerID = component.MustNewType("configprovider")
+
+// Watchable is implemented by a ConfigSource that can notify the manager when a
+// previously retrieved value is no longer current. Implementing it is optional: a
+// source that has no way to detect staleness simply doesn't satisfy the interface,
+// and the manager skips it during fan-in.
+type Watchable interface {
+	// WatchForUpdate returns a channel that receives a (possibly nil) error every
+	// time the source believes a resolved value has rotated. The channel is closed
+	// when the source itself shuts down.
+	WatchForUpdate() <-chan error
+}
+
+// Manager owns the set of ConfigSource instances referenced by a configuration and
+// resolves ${type:selector} expansions against them.
+type Manager struct {
+	telemetry component.TelemetrySettings
+
+	sources map[string]ConfigSource
+
+	watcherMu sync.Mutex
+	watcher   confmap.WatcherFunc
+	watchWG   sync.WaitGroup
+
+	storageIDs map[string]component.ID
+	cacheCfg   map[string]cacheConfig
+
+	storageMu sync.Mutex
+	clients   map[string]StorageClient
+	refreshWG sync.WaitGroup
+
+	decodeHooksMu     sync.RWMutex
+	decodeHooks       map[string]mapstructure.DecodeHookFunc
+	decodeHookTargets map[string]reflect.Type
+}
+
+// NewManager creates a Manager, eagerly instantiating a ConfigSource for every
+// factory given. factories must not contain two entries with the same Type().
+// rawConfigSources carries the user-provided "config_sources" section of the
+// collector's configuration, keyed by config source type (e.g. "vault"), so NewManager
+// can unmarshal each factory's Settings against what the operator actually wrote
+// instead of running every source on its factory's hardcoded defaults.
+func NewManager(factories []Factory, rawConfigSources map[string]any, telemetry component.TelemetrySettings, buildInfo component.BuildInfo) (*Manager, error) {
+	m := &Manager{
+		telemetry:  telemetry,
+		sources:    make(map[string]ConfigSource, len(factories)),
+		storageIDs: make(map[string]component.ID),
+		cacheCfg:   make(map[string]cacheConfig),
+		clients:    make(map[string]StorageClient),
+		decodeHooks: map[string]mapstructure.DecodeHookFunc{
+			"duration": stringToDurationHookFunc(),
+			"ip":       stringToIPHookFunc(),
+			"url":      stringToURLHookFunc(),
+		},
+		decodeHookTargets: make(map[string]reflect.Type, len(builtinDecodeHookTarget)),
+	}
+	for name, targetType := range builtinDecodeHookTarget {
+		m.decodeHookTargets[name] = targetType
+	}
+
+	// abort tears down every config source already created before a later factory
+	// fails, so a mid-build error doesn't leak the earlier sources' fan-in goroutines
+	// (or anything else they hold open) along with the Manager we're about to discard.
+	abort := func() {
+		ctx := context.Background()
+		for _, cs := range m.sources {
+			if err := cs.Shutdown(ctx); err != nil {
+				m.telemetry.Logger.Warn("failed to shut down config source after a later one failed to build", zap.Error(err))
+			}
+		}
+		m.watchWG.Wait()
+	}
+
+	for _, f := range factories {
+		if _, ok := m.sources[f.Type()]; ok {
+			abort()
+			return nil, fmt.Errorf("duplicate config source factory %q", f.Type())
+		}
+
+		cfg := f.CreateDefaultSettings()
+		if raw, ok := rawConfigSources[f.Type()]; ok {
+			if err := decodeSettings(raw, &cfg); err != nil {
+				abort()
+				return nil, fmt.Errorf("config source %q: failed to decode settings: %w", f.Type(), err)
+			}
+		}
+
+		cs, err := f.CreateConfigSource(CreateParams{TelemetrySettings: telemetry, BuildInfo: buildInfo}, cfg)
+		if err != nil {
+			abort()
+			return nil, fmt.Errorf("failed to create config source %s: %w", f.Type(), err)
+		}
+		m.sources[f.Type()] = cs
+
+		if watchable, ok := cs.(Watchable); ok && cfg.WatchEnabled {
+			m.fanIn(f.Type(), watchable)
+		}
+
+		if cfg.StorageID != nil {
+			m.storageIDs[f.Type()] = *cfg.StorageID
+			m.cacheCfg[f.Type()] = cacheConfig{ttl: cfg.CacheTTL, staleIfError: cfg.StaleIfError}
+		}
+	}
+
+	return m, nil
+}
+
+// SetHost resolves a StorageClient for every config source that opted into caching
+// via Settings.StorageID, using host's extension graph. It must be called once the
+// collector's extensions are up; until then (in particular, during the very first
+// Retrieve of a cold start) caching is simply inactive and the manager talks to
+// config sources directly, the same as if StorageID had been left unset.
+func (m *Manager) SetHost(ctx context.Context, host component.Host) error {
+	m.storageMu.Lock()
+	defer m.storageMu.Unlock()
+
+	for sourceType, storageID := range m.storageIDs {
+		ext, ok := host.GetExtensions()[storageID]
+		if !ok {
+			return fmt.Errorf("config source %q: storage extension %q not found", sourceType, storageID)
+		}
+		se, ok := ext.(StorageExtension)
+		if !ok {
+			return fmt.Errorf("config source %q: extension %q does not provide a storage client", sourceType, storageID)
+		}
+		// sourceType is passed as the storage name so two config sources sharing one
+		// storage extension land in separate namespaces, the same way exporterhelper
+		// shards its persistent queue clients by exporter.
+		client, err := se.GetClient(ctx, component.KindExtension, component.NewID(configSourceOwnerID), sourceType)
+		if err != nil {
+			return fmt.Errorf("config source %q: failed to get storage client %q: %w", sourceType, storageID, err)
+		}
+		m.clients[sourceType] = client
+	}
+	return nil
+}
+
+// fanIn starts a goroutine that forwards update notifications from a single watchable
+// config source to the watcher most recently registered with the manager, if any.
+func (m *Manager) fanIn(sourceType string, w Watchable) {
+	m.watchWG.Add(1)
+	go func() {
+		defer m.watchWG.Done()
+		for err := range w.WatchForUpdate() {
+			if err != nil {
+				m.telemetry.Logger.Warn("config source reported a change", zap.String("config_source", sourceType), zap.Error(err))
+			}
+			m.notifyWatchers(err)
+		}
+	}()
+}
+
+// notifyWatchers forwards a config source change event to the confmap.WatcherFunc
+// most recently handed to Resolve, if any.
+func (m *Manager) notifyWatchers(err error) {
+	m.watcherMu.Lock()
+	watcher := m.watcher
+	m.watcherMu.Unlock()
+
+	if watcher != nil {
+		watcher(&confmap.ChangeEvent{Error: err})
+	}
+}
+
+// registerWatcher remembers watcher so future config source change events reach it,
+// replacing whatever watcher a previous Resolve registered. The real confmap.Resolver
+// this feature targets calls Retrieve again on every re-resolve over the lifetime of a
+// single Resolver, each time with its own onChange callback - the new registration
+// supersedes the old one rather than stacking alongside it, so a long-running collector
+// doesn't grow an ever-larger slice of stale watchers that each re-deliver the same
+// event.
+func (m *Manager) registerWatcher(watcher confmap.WatcherFunc) {
+	if watcher == nil {
+		return
+	}
+	m.watcherMu.Lock()
+	m.watcher = watcher
+	m.watcherMu.Unlock()
+}
+
+// Resolve walks raw and replaces every whole-value ${type:selector} reference with the
+// value returned by the matching ConfigSource. watcher, when non-nil, is forwarded to
+// every config source called during this Resolve so they can request a re-resolve
+// later (a background cache refresh calls the source with a nil watcher); it is also kept
+// so that sources which detect a change outside of the current Retrieve call (fan-in
+// via Watchable) can still trigger one.
+func (m *Manager) Resolve(ctx context.Context, raw map[string]any, watcher confmap.WatcherFunc) (map[string]any, error) {
+	m.registerWatcher(watcher)
+
+	resolved, err := m.resolveValue(ctx, raw, watcher)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]any), nil
+}
+
+func (m *Manager) resolveValue(ctx context.Context, v any, watcher confmap.WatcherFunc) (any, error) {
+	switch val := v.(type) {
+	case string:
+		return m.resolveString(ctx, val, watcher)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, child := range val {
+			resolvedChild, err := m.resolveValue(ctx, child, watcher)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolvedChild
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, child := range val {
+			resolvedChild, err := m.resolveValue(ctx, child, watcher)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolvedChild
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func (m *Manager) resolveString(ctx context.Context, s string, watcher confmap.WatcherFunc) (any, error) {
+	if !isConfigSourceRef(s) {
+		return s, nil
+	}
+	ref, ok := m.parseConfigSourceRef(s)
+	if !ok {
+		return s, nil
+	}
+
+	cs, ok := m.sources[ref.sourceType]
+	if !ok {
+		return nil, fmt.Errorf("config source %q not found for selector %q", ref.sourceType, ref.selector)
+	}
+
+	value, err := m.retrieveRef(ctx, ref, cs, watcher)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.decodeHook == "" {
+		return value, nil
+	}
+	decoded, err := m.applyDecodeHook(ref.decodeHook, value)
+	if err != nil {
+		return nil, fmt.Errorf("config source %q: selector %q: %w", ref.sourceType, ref.selector, err)
+	}
+	return decoded, nil
+}
+
+// retrieveRef fetches the raw value for ref, going through the cache when one is
+// configured for ref.sourceType.
+func (m *Manager) retrieveRef(ctx context.Context, ref configSourceRef, cs ConfigSource, watcher confmap.WatcherFunc) (any, error) {
+	m.storageMu.Lock()
+	client := m.clients[ref.sourceType]
+	cc := m.cacheCfg[ref.sourceType]
+	m.storageMu.Unlock()
+
+	if client == nil {
+		retrieved, err := cs.Retrieve(ctx, ref.selector, nil, watcher)
+		if err != nil {
+			return nil, fmt.Errorf("config source %q failed to retrieve value: %w", ref.sourceType, err)
+		}
+		return retrieved.Value, nil
+	}
+	return m.resolveWithCache(ctx, ref, cs, client, cc, watcher)
+}
+
+// resolveWithCache implements the cached retrieval path: serve a still-fresh cached
+// value directly (kicking off an async refresh behind it), otherwise revalidate
+// against the source, falling back to the cache on failure when cc.staleIfError
+// allows it.
+func (m *Manager) resolveWithCache(ctx context.Context, ref configSourceRef, cs ConfigSource, client StorageClient, cc cacheConfig, watcher confmap.WatcherFunc) (any, error) {
+	key := cacheKey(ref.sourceType, ref.selector, nil)
+	cached, cacheErr := readCache(ctx, client, key)
+
+	if cacheErr == nil && cc.ttl > 0 && time.Since(cached.RetrievedAt) < cc.ttl {
+		m.refreshWG.Add(1)
+		go m.refreshCache(ref, cs, client, key)
+		return cached.Value, nil
+	}
+
+	retrieved, err := cs.Retrieve(ctx, ref.selector, nil, watcher)
+	if err != nil {
+		if cc.staleIfError && cacheErr == nil {
+			m.telemetry.Logger.Warn("config source retrieval failed, serving last cached value",
+				zap.String("config_source", ref.sourceType), zap.Error(err))
+			return cached.Value, nil
+		}
+		return nil, fmt.Errorf("config source %q failed to retrieve value: %w", ref.sourceType, err)
+	}
+
+	writeCache(ctx, client, key, retrieved.Value, m.telemetry.Logger)
+	return retrieved.Value, nil
+}
+
+// refreshCache re-fetches a value that was just served from a fresh-enough cache
+// entry, persisting the result so the next TTL window starts from current data. It
+// runs detached from the Retrieve call that triggered it: a slow or failing refresh
+// must never delay the configuration resolve that already has a good answer. Shutdown
+// waits on refreshWG before shutting down ConfigSources or closing storage clients, so
+// cs and client are both guaranteed to still be live for the duration of this call.
+func (m *Manager) refreshCache(ref configSourceRef, cs ConfigSource, client StorageClient, key string) {
+	defer m.refreshWG.Done()
+	ctx := context.Background()
+	retrieved, err := cs.Retrieve(ctx, ref.selector, nil, nil)
+	if err != nil {
+		m.telemetry.Logger.Warn("background refresh of cached config source value failed",
+			zap.String("config_source", ref.sourceType), zap.Error(err))
+		return
+	}
+	writeCache(ctx, client, key, retrieved.Value, m.telemetry.Logger)
+}
+
+// Shutdown tears down every ConfigSource created by the manager and waits for fan-in
+// and background cache-refresh goroutines to drain.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	// Wait for every in-flight refreshCache goroutine to finish before tearing down the
+	// ConfigSources below - otherwise a refresh could still be calling cs.Retrieve on a
+	// ConfigSource we're about to Shutdown.
+	m.refreshWG.Wait()
+
+	var err error
+	for _, cs := range m.sources {
+		if shutdownErr := cs.Shutdown(ctx); shutdownErr != nil {
+			err = shutdownErr
+		}
+	}
+
+	m.storageMu.Lock()
+	clients := m.clients
+	m.storageMu.Unlock()
+	for _, client := range clients {
+		if closeErr := client.Close(ctx); closeErr != nil {
+			err = closeErr
+		}
+	}
+
+	m.watchWG.Wait()
+	return err
+}